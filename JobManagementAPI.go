@@ -2,62 +2,30 @@ package main
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/emrserverless"
-	"github.com/golang-jwt/jwt"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	_ "github.com/lib/pq"
 	log "github.com/sirupsen/logrus"
-)
-
-type FailureResponse struct {
-	Id      string `json:"id"`
-	Message string `json:"message"`
-}
-
-type SuccessResponse struct {
-	Id        string `json:"id"`
-	JobId     string `json:"jobId"`
-	RequestId string `json:"requestId"`
-	JobStatus string `json:"jobStatus,omitempty"`
-	Message   string `json:"message,omitempty"`
-}
-
-type JobDetail struct {
-	Id          string `json:"id"`
-	JobId       string `json:"jobId"`
-	JobStatus   string `json:"jobStatus"`
-	RequestId   string `json:"requestId"`
-	Query       string `json:"query"`
-	Destination string `json:"destination"`
-	Jti         string `json:"jti"`
-	Region      string `json:"cross_bucket_region"`
-}
 
-type SkyflowAuthorizationResponse struct {
-	RequestId    string `json:"requestId"`
-	StatusCode   int    `json:"statusCode"`
-	ResponseBody string `json:"responseBody"`
-	Error        string `json:"error"`
-}
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/api"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/auth"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/emr"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/logs"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/repository"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/skyflow"
+)
 
-var logger *log.Entry
-var db *sql.DB
-var managementUrl string
-var service *emrserverless.EMRServerless
-var applicationId string
-var validVaultIds []string
-var source string
+var handler *api.Handler
 
 func init() {
 	log.SetFormatter(&log.JSONFormatter{})
@@ -76,315 +44,64 @@ func init() {
 		password,
 		databaseName,
 	)
-	db, _ = sql.Open("postgres", connection)
+	db, _ := sql.Open("postgres", connection)
 
 	sess, _ := session.NewSession(&aws.Config{
 		Region: aws.String(os.Getenv("REGION")),
 	})
-	service = emrserverless.New(sess)
-
-	managementUrl = os.Getenv("MANAGEMENT_URL")
-
-	applicationId = os.Getenv("APPLICATION_ID")
-
-	validVaultIds = strings.Split(os.Getenv("VALID_VAULT_IDS"), ",")
-
-	source = "JobManagementAPI"
-}
-
-func main() {
-	lambda.Start(HandleRequest)
-}
-
-func HandleRequest(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	apiResponse := events.APIGatewayProxyResponse{}
-
-	id := request.PathParameters["jobID"]
-	logger = log.WithFields(log.Fields{
-		"queryId": id,
-		"source":  source,
-	})
-
-	logger.Info(fmt.Sprintf("Initiated %v", source))
-
-	clientIpAddress := strings.Split(request.Headers["X-Forwarded-For"], ",")[0]
-	logger.Info(fmt.Sprintf("Client IP address: %v", clientIpAddress))
-
-	logger = logger.WithFields(log.Fields{
-		"clientIp": clientIpAddress,
-	})
-
-	vaultId := request.PathParameters["vaultID"]
-	token := request.Headers["Authorization"]
-
-	authSchemeValidation := ValidateAuthScheme(token)
-	if !authSchemeValidation {
-		responseBody, _ := json.Marshal(FailureResponse{
-			Id:      id,
-			Message: "Auth Scheme not supported",
-		})
-
-		apiResponse.Body = string(responseBody)
-		apiResponse.StatusCode = http.StatusUnauthorized
-
-		return apiResponse, nil
-	}
-
-	jti, err := ExtractJTI(token)
-	if err != nil {
-		responseBody, _ := json.Marshal(FailureResponse{
-			Id:      id,
-			Message: fmt.Sprintf("Failed to extract jti with error: %v", err.Error()),
-		})
-
-		apiResponse.Body = string(responseBody)
-		apiResponse.StatusCode = http.StatusForbidden
-
-		return apiResponse, nil
-	}
-
-	logger = logger.WithFields(log.Fields{
-		"jti": jti,
-	})
 
-	validVaultIdValidation := ValidateVaultId(vaultId)
-	if !validVaultIdValidation {
-		responseBody, _ := json.Marshal(FailureResponse{
-			Id:      id,
-			Message: "Invalid Vault ID",
-		})
-
-		apiResponse.Body = string(responseBody)
-		apiResponse.StatusCode = http.StatusForbidden
-
-		return apiResponse, nil
+	var logSource *logs.Source
+	if logBucket := os.Getenv("LOG_BUCKET"); logBucket != "" {
+		logSource = logs.NewSource(s3.New(sess), logBucket)
 	}
 
-	authResponse := SkyflowAuthorization(token, vaultId, id)
-	if authResponse.Error != "" {
-		responseBody, _ := json.Marshal(FailureResponse{
-			Id:      id,
-			Message: authResponse.Error,
-		})
-
-		apiResponse.Body = string(responseBody)
-		apiResponse.StatusCode = authResponse.StatusCode
-		return apiResponse, nil
-	}
-
-	if authResponse.StatusCode != http.StatusOK {
-		responseBody, _ := json.Marshal(FailureResponse{
-			Id:      id,
-			Message: authResponse.ResponseBody,
-		})
-
-		apiResponse.Body = string(responseBody)
-		apiResponse.StatusCode = authResponse.StatusCode
-		return apiResponse, nil
-	}
-
-	logger = logger.WithFields(log.Fields{
-		"skyflowRequestId": authResponse.RequestId,
+	handler = api.NewHandler(api.Config{
+		Verifier: auth.NewVerifierFromEnv(),
+		Skyflow:  skyflow.NewClient(os.Getenv("MANAGEMENT_URL")),
+		Jobs:     repository.NewJobRepository(db),
+		EMR:      emr.NewClient(emrserverless.New(sess)),
+		Logs:     logSource,
+		NewOutputS3: func(region string) (s3iface.S3API, error) {
+			outputSess, err := session.NewSession()
+			if err != nil {
+				return nil, err
+			}
+			return s3.New(outputSess, &aws.Config{Region: aws.String(region)}), nil
+		},
+		ApplicationId:       os.Getenv("APPLICATION_ID"),
+		ExecutionRoleArn:    os.Getenv("EXECUTION_ROLE_ARN"),
+		SparkEntryPointJar:  os.Getenv("SPARK_ENTRY_POINT_JAR"),
+		LogPrefix:           os.Getenv("LOG_PREFIX"),
+		ValidVaultIds:       strings.Split(os.Getenv("VALID_VAULT_IDS"), ","),
+		RequiredRolesGet:    parseRoleList(os.Getenv("REQUIRED_ROLES_GET")),
+		RequiredRolesDelete: parseRoleList(os.Getenv("REQUIRED_ROLES_DELETE")),
+		RequiredRolesRetry:  parseRoleList(os.Getenv("REQUIRED_ROLES_RETRY")),
+		PresignMaxTTL:       parseSecondsDuration(os.Getenv("PRESIGN_MAX_TTL"), time.Hour),
+		Source:              "JobManagementAPI",
 	})
-
-	logger.Info("Sucessfully Authorized")
-
-	logger.Info(fmt.Sprintf("Checking record for id: %v", id))
-
-	jobDetail, err := GetJobDetail(id)
-	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to get job details for id: %v with error: %v", id, err.Error()))
-
-		responseBody, _ := json.Marshal(FailureResponse{
-			Id:      id,
-			Message: fmt.Sprintf("Failed to check record for id: %v with error: %v\n", id, err.Error()),
-		})
-
-		apiResponse.Body = string(responseBody)
-		apiResponse.StatusCode = http.StatusBadRequest
-		return apiResponse, nil
-	}
-
-	logger = logger.WithFields(log.Fields{
-		"query":             jobDetail.Query,
-		"destinationBucket": jobDetail.Destination,
-		"region":            jobDetail.Region,
-	})
-
-	logger.Info("Successfully Executed query")
-
-	if request.HTTPMethod == "GET" {
-		responseBody, _ := json.Marshal(SuccessResponse{
-			Id:        jobDetail.Id,
-			JobId:     jobDetail.JobId,
-			JobStatus: jobDetail.JobStatus,
-			RequestId: jobDetail.RequestId,
-		})
-
-		apiResponse.Body = string(responseBody)
-		apiResponse.StatusCode = http.StatusOK
-		return apiResponse, nil
-	}
-
-	if request.HTTPMethod == "DELETE" {
-		if jobDetail.JobStatus == "SUCCESS" || jobDetail.JobStatus == "FAILURE" {
-			responseBody, _ := json.Marshal(FailureResponse{
-				Id:      id,
-				Message: fmt.Sprintf("Job for jobId:%v is already completed", jobDetail.JobId),
-			})
-			apiResponse.Body = string(responseBody)
-			apiResponse.StatusCode = http.StatusBadRequest
-			return apiResponse, nil
-		}
-
-		if jobDetail.JobStatus == "CANCELLING" || jobDetail.JobStatus == "CANCELLED" {
-			responseBody, _ := json.Marshal(FailureResponse{
-				Id:      id,
-				Message: fmt.Sprintf("Job for jobId:%v is already cancelled", jobDetail.JobId),
-			})
-			apiResponse.Body = string(responseBody)
-			apiResponse.StatusCode = http.StatusBadRequest
-			return apiResponse, nil
-		}
-
-		params := &emrserverless.CancelJobRunInput{
-			ApplicationId: aws.String(applicationId),
-			JobRunId:      aws.String(jobDetail.JobId),
-		}
-
-		logger.Info("Cancelling job")
-
-		_, err := service.CancelJobRun(params)
-		if err != nil {
-			responseBody, _ := json.Marshal(FailureResponse{
-				Id:      id,
-				Message: fmt.Sprintf("Failed to cancel job for jobId: %v with error: %v\n", jobDetail.JobId, err.Error()),
-			})
-
-			apiResponse.Body = string(responseBody)
-			apiResponse.StatusCode = http.StatusBadRequest
-			return apiResponse, nil
-		}
-
-		logger.Info("Successfully cancelled job")
-
-		responseBody, _ := json.Marshal(SuccessResponse{
-			Id:        jobDetail.Id,
-			JobId:     jobDetail.JobId,
-			RequestId: jobDetail.RequestId,
-			Message:   "Successfully deleted",
-		})
-
-		apiResponse.Body = string(responseBody)
-		apiResponse.StatusCode = http.StatusOK
-		return apiResponse, nil
-	}
-
-	return apiResponse, nil
 }
 
-func GetJobDetail(id string) (JobDetail, error) {
-	logger.Info("Initiating GetJobDetail")
-
-	statement := `SELECT id, jobid, jobstatus, requestid, query, destination, jti, cross_bucket_region FROM emr_job_details WHERE id=$1`
-	var jobDetail JobDetail
-
-	record := db.QueryRow(statement, id)
-
-	switch err := record.Scan(
-		&jobDetail.Id,
-		&jobDetail.JobId,
-		&jobDetail.JobStatus,
-		&jobDetail.RequestId,
-		&jobDetail.Query,
-		&jobDetail.Destination,
-		&jobDetail.Jti,
-		&jobDetail.Region,
-	); err {
-	case sql.ErrNoRows:
-		return jobDetail, sql.ErrNoRows
-	case nil:
-		return jobDetail, nil
-	default:
-		return jobDetail, err
-	}
-}
-
-func SkyflowAuthorization(token string, vaultId string, id string) SkyflowAuthorizationResponse {
-	var authResponse SkyflowAuthorizationResponse
-
-	logger.Info("Initiating SkyflowAuthorization")
-
-	client := &http.Client{Timeout: 1 * time.Minute}
-	var url = managementUrl + "/v1/vaults/" + vaultId
-
-	logger.Info("Initiating Skyflow Request for Authorization")
-
-	request, _ := http.NewRequest("GET", url, nil)
-	request.Header.Add("Accept", "apaplication/json")
-	request.Header.Add("Content-Type", "application/json")
-	request.Header.Add("Authorization", token)
-
-	response, err := client.Do(request)
-	if err != nil {
-		logger.Error(fmt.Sprintf("Got error on Skyflow Validation request: %v", err.Error()))
-
-		authResponse.StatusCode = http.StatusInternalServerError
-		authResponse.Error = err.Error()
-		return authResponse
-	}
-
-	responseBody, _ := io.ReadAll(response.Body)
-	defer response.Body.Close()
-
-	authResponse.RequestId = response.Header.Get("x-request-id")
-	authResponse.StatusCode = response.StatusCode
-	authResponse.ResponseBody = string(responseBody)
-
-	if response.StatusCode != http.StatusOK {
-		logger.Error(fmt.Sprintf("Unable/Fail to call Skyflow API status code:%v and message:%v", response.StatusCode, string(responseBody)))
-	}
-
-	return authResponse
-}
-
-func ValidateAuthScheme(token string) bool {
-	logger.Info("Initiating ValidateAuthScheme")
-
-	authScheme := strings.Split(token, " ")[0]
-
-	if authScheme != "Bearer" {
-		return false
-	}
-	return true
+func main() {
+	lambda.Start(handler.HandleRequest)
 }
 
-func ValidateVaultId(vaultId string) bool {
-	logger.Info("Initiating ValidateVaultId")
-
-	for _, validVaultId := range validVaultIds {
-		if vaultId == validVaultId {
-			return true
+func parseRoleList(env string) []string {
+	var roles []string
+	for _, role := range strings.Split(env, ",") {
+		role = strings.TrimSpace(role)
+		if role != "" {
+			roles = append(roles, role)
 		}
 	}
-	return false
+	return roles
 }
 
-func ExtractJTI(authToken string) (string, error) {
-	logger.Info("Initiating ExtractJTI")
-
-	tokenString := strings.Split(authToken, " ")[1]
-
-	logger.Info("Initiating token parsing")
-	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
-	if err != nil {
-		logger.Error(fmt.Sprintf("Got error: %v in token parsing", err.Error()))
-		return "", err
+// parseSecondsDuration parses env as a whole number of seconds, falling back
+// to fallback when env is empty or not a positive integer.
+func parseSecondsDuration(env string, fallback time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(env)
+	if err != nil || seconds <= 0 {
+		return fallback
 	}
-
-	logger.Info("Successfully parsed token")
-	claims := token.Claims.(jwt.MapClaims)
-	jti := claims["jti"].(string)
-
-	return jti, nil
+	return time.Duration(seconds) * time.Second
 }
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type BulkCancelRequest struct {
+	Ids    []string `json:"ids"`
+	DryRun bool     `json:"dryRun"`
+}
+
+type BulkCancelFailure struct {
+	Id     string `json:"id"`
+	Reason string `json:"reason"`
+	Code   string `json:"code"`
+}
+
+type BulkCancelResponse struct {
+	Cancelled []string            `json:"cancelled"`
+	Failed    []BulkCancelFailure `json:"failed"`
+}
+
+type cancelResult struct {
+	id     string
+	ok     bool
+	reason string
+	code   string
+}
+
+// HandleBulkCancel cancels a batch of jobs concurrently, reporting a
+// per-item outcome.
+func (h *Handler) HandleBulkCancel(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	apiResponse := events.APIGatewayProxyResponse{}
+
+	var body BulkCancelRequest
+	if err := json.Unmarshal([]byte(request.Body), &body); err != nil {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Message: fmt.Sprintf("Invalid request body: %v", err.Error()),
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusBadRequest
+		return apiResponse, nil
+	}
+
+	if len(body.Ids) == 0 {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Message: "ids must not be empty",
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusBadRequest
+		return apiResponse, nil
+	}
+
+	if len(body.Ids) > maxBulkCancelIds {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Message: fmt.Sprintf("at most %v ids may be cancelled at once", maxBulkCancelIds),
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusBadRequest
+		return apiResponse, nil
+	}
+
+	h.logger.Info(fmt.Sprintf("Bulk cancelling %v jobs, dryRun=%v", len(body.Ids), body.DryRun))
+
+	result := h.CancelJobs(ctx, body.Ids, body.DryRun)
+
+	h.logger.Info(fmt.Sprintf("Bulk cancel finished: %v cancelled, %v failed", len(result.Cancelled), len(result.Failed)))
+
+	responseBody, _ := json.Marshal(result)
+	apiResponse.Body = string(responseBody)
+	apiResponse.StatusCode = http.StatusOK
+	return apiResponse, nil
+}
+
+// CancelJobs fans out cancellation of ids across a bounded worker pool and
+// reports a per-item outcome rather than aborting on the first failure. When
+// dryRun is true, jobs are validated but CancelJobRun is never called.
+func (h *Handler) CancelJobs(ctx context.Context, ids []string, dryRun bool) BulkCancelResponse {
+	jobs := make(chan string)
+	results := make(chan cancelResult, len(ids))
+
+	var wg sync.WaitGroup
+	for w := 0; w < bulkCancelConcurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				results <- h.cancelOne(ctx, id, dryRun)
+			}
+		}()
+	}
+
+	go func() {
+		for _, id := range ids {
+			jobs <- id
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	response := BulkCancelResponse{}
+	for r := range results {
+		if r.ok {
+			response.Cancelled = append(response.Cancelled, r.id)
+		} else {
+			response.Failed = append(response.Failed, BulkCancelFailure{Id: r.id, Reason: r.reason, Code: r.code})
+		}
+	}
+	return response
+}
+
+func (h *Handler) cancelOne(ctx context.Context, id string, dryRun bool) cancelResult {
+	job, err := h.cfg.Jobs.Get(ctx, id)
+	if err != nil {
+		return cancelResult{id: id, reason: fmt.Sprintf("Failed to check record for id: %v with error: %v", id, err.Error()), code: "not_found"}
+	}
+
+	if job.JobStatus == "SUCCESS" || job.JobStatus == "FAILURE" {
+		return cancelResult{id: id, reason: fmt.Sprintf("Job for jobId:%v is already completed", job.JobId), code: "already_completed"}
+	}
+
+	if job.JobStatus == "CANCELLING" || job.JobStatus == "CANCELLED" {
+		return cancelResult{id: id, reason: fmt.Sprintf("Job for jobId:%v is already cancelled", job.JobId), code: "already_cancelled"}
+	}
+
+	if dryRun {
+		return cancelResult{id: id, ok: true}
+	}
+
+	if err := h.cfg.EMR.CancelJobRun(ctx, h.cfg.ApplicationId, job.JobId); err != nil {
+		return cancelResult{id: id, reason: fmt.Sprintf("Failed to cancel job for jobId: %v with error: %v", job.JobId, err.Error()), code: "cancel_failed"}
+	}
+
+	return cancelResult{id: id, ok: true}
+}
@@ -0,0 +1,354 @@
+// Package api implements the JobManagementAPI Lambda's HTTP handler against
+// injected dependencies, so it can be exercised in tests without a real
+// database, S3 bucket, or EMR Serverless application.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/auth"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/emr"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/logs"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/repository"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/skyflow"
+)
+
+const (
+	maxBulkCancelIds        = 100
+	bulkCancelConcurrency   = 10
+	defaultLogBacklogLines  = 200
+	logPollInterval         = 2 * time.Second
+	logFollowSafetyMargin   = 5 * time.Second
+	defaultPresignTTL       = 15 * time.Minute
+	defaultPresignMaxTTL    = 1 * time.Hour
+	idempotencyTTL          = 24 * time.Hour
+	idempotencyWaitBudget   = 10 * time.Second
+	idempotencyPollInterval = 250 * time.Millisecond
+)
+
+// FailureResponse is the JSON body returned on any 4xx/5xx response.
+type FailureResponse struct {
+	Id      string `json:"id"`
+	Message string `json:"message"`
+}
+
+// SuccessResponse is the JSON body returned for single-job status/cancel
+// requests.
+type SuccessResponse struct {
+	Id        string `json:"id"`
+	JobId     string `json:"jobId"`
+	RequestId string `json:"requestId"`
+	JobStatus string `json:"jobStatus,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// Config holds every dependency and piece of configuration a Handler needs.
+// The main package is responsible for constructing concrete implementations and
+// populating this from the environment.
+type Config struct {
+	Verifier            *auth.Verifier
+	Skyflow             *skyflow.Client
+	Jobs                *repository.JobRepository
+	EMR                 emr.Client
+	Logs                *logs.Source
+	NewOutputS3         func(region string) (s3iface.S3API, error)
+	ApplicationId       string
+	ExecutionRoleArn    string
+	SparkEntryPointJar  string
+	LogPrefix           string
+	ValidVaultIds       []string
+	RequiredRolesGet    []string
+	RequiredRolesDelete []string
+	RequiredRolesRetry  []string
+	PresignMaxTTL       time.Duration
+	Source              string
+}
+
+// Handler serves the JobManagementAPI Lambda's single entry point.
+type Handler struct {
+	cfg    Config
+	logger *log.Entry
+}
+
+// NewHandler builds a Handler from cfg.
+func NewHandler(cfg Config) *Handler {
+	return &Handler{cfg: cfg}
+}
+
+// HandleRequest is the Lambda entry point: it authenticates and authorizes
+// the caller, then routes to the appropriate sub-handler.
+func (h *Handler) HandleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	apiResponse := events.APIGatewayProxyResponse{}
+
+	id := request.PathParameters["jobID"]
+	h.logger = log.WithFields(log.Fields{
+		"queryId": id,
+		"source":  h.cfg.Source,
+	})
+
+	h.logger.Info(fmt.Sprintf("Initiated %v", h.cfg.Source))
+
+	clientIpAddress := strings.Split(request.Headers["X-Forwarded-For"], ",")[0]
+	h.logger.Info(fmt.Sprintf("Client IP address: %v", clientIpAddress))
+
+	h.logger = h.logger.WithFields(log.Fields{
+		"clientIp": clientIpAddress,
+	})
+
+	vaultId := request.PathParameters["vaultID"]
+	token := request.Headers["Authorization"]
+
+	if !ValidateAuthScheme(token) {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      id,
+			Message: "Auth Scheme not supported",
+		})
+
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusUnauthorized
+
+		return apiResponse, nil
+	}
+
+	claims, err := h.cfg.Verifier.Verify(token)
+	if err != nil {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      id,
+			Message: authErrorMessage(err),
+		})
+
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusUnauthorized
+
+		return apiResponse, nil
+	}
+
+	h.logger = h.logger.WithFields(log.Fields{
+		"jti":   claims.Jti,
+		"sub":   claims.Sub,
+		"roles": claims.Roles,
+	})
+
+	requiredRoles := h.requiredRolesForRequest(request)
+	if !claims.HasAllRoles(requiredRoles) {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      id,
+			Message: fmt.Sprintf("missing '%v' role", strings.Join(requiredRoles, ",")),
+		})
+
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusForbidden
+
+		return apiResponse, nil
+	}
+
+	if !h.ValidateVaultId(vaultId) {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      id,
+			Message: "Invalid Vault ID",
+		})
+
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusForbidden
+
+		return apiResponse, nil
+	}
+
+	authResponse := h.cfg.Skyflow.Authorize(token, vaultId)
+	if authResponse.Error != "" {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      id,
+			Message: authResponse.Error,
+		})
+
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = authResponse.StatusCode
+		return apiResponse, nil
+	}
+
+	if authResponse.StatusCode != http.StatusOK {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      id,
+			Message: authResponse.ResponseBody,
+		})
+
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = authResponse.StatusCode
+		return apiResponse, nil
+	}
+
+	h.logger = h.logger.WithFields(log.Fields{
+		"skyflowRequestId": authResponse.RequestId,
+	})
+
+	h.logger.Info("Sucessfully Authorized")
+
+	if id == "" {
+		switch {
+		case request.HTTPMethod == "GET":
+			return h.HandleListJobs(ctx, request, claims)
+		case request.HTTPMethod == "POST" && strings.HasSuffix(request.Path, ":cancel"):
+			return h.HandleBulkCancel(ctx, request)
+		}
+	}
+
+	h.logger.Info(fmt.Sprintf("Checking record for id: %v", id))
+
+	job, err := h.cfg.Jobs.Get(ctx, id)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("Failed to get job details for id: %v with error: %v", id, err.Error()))
+
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      id,
+			Message: fmt.Sprintf("Failed to check record for id: %v with error: %v\n", id, err.Error()),
+		})
+
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusBadRequest
+		return apiResponse, nil
+	}
+
+	h.logger = h.logger.WithFields(log.Fields{
+		"query":             job.Query,
+		"destinationBucket": job.Destination,
+		"region":            job.Region,
+	})
+
+	h.logger.Info("Successfully Executed query")
+
+	if request.HTTPMethod == "GET" && strings.HasSuffix(request.Path, "/logs") {
+		return h.HandleGetLogs(ctx, request, job)
+	}
+
+	if request.HTTPMethod == "GET" && strings.HasSuffix(request.Path, "/output") {
+		return h.HandleGetOutput(ctx, request, job)
+	}
+
+	if request.HTTPMethod == "POST" && strings.HasSuffix(request.Path, ":retry") {
+		return h.HandleRetryJob(ctx, job, request.Headers["Idempotency-Key"])
+	}
+
+	if request.HTTPMethod == "GET" {
+		responseBody, _ := json.Marshal(SuccessResponse{
+			Id:        job.Id,
+			JobId:     job.JobId,
+			JobStatus: job.JobStatus,
+			RequestId: job.RequestId,
+		})
+
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusOK
+		return apiResponse, nil
+	}
+
+	if request.HTTPMethod == "DELETE" {
+		return h.HandleCancelJob(ctx, job)
+	}
+
+	return apiResponse, nil
+}
+
+func (h *Handler) HandleCancelJob(ctx context.Context, job repository.Job) (events.APIGatewayProxyResponse, error) {
+	apiResponse := events.APIGatewayProxyResponse{}
+
+	if job.JobStatus == "SUCCESS" || job.JobStatus == "FAILURE" {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      job.Id,
+			Message: fmt.Sprintf("Job for jobId:%v is already completed", job.JobId),
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusBadRequest
+		return apiResponse, nil
+	}
+
+	if job.JobStatus == "CANCELLING" || job.JobStatus == "CANCELLED" {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      job.Id,
+			Message: fmt.Sprintf("Job for jobId:%v is already cancelled", job.JobId),
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusBadRequest
+		return apiResponse, nil
+	}
+
+	h.logger.Info("Cancelling job")
+
+	if err := h.cfg.EMR.CancelJobRun(ctx, h.cfg.ApplicationId, job.JobId); err != nil {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      job.Id,
+			Message: fmt.Sprintf("Failed to cancel job for jobId: %v with error: %v\n", job.JobId, err.Error()),
+		})
+
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusBadRequest
+		return apiResponse, nil
+	}
+
+	h.logger.Info("Successfully cancelled job")
+
+	responseBody, _ := json.Marshal(SuccessResponse{
+		Id:        job.Id,
+		JobId:     job.JobId,
+		RequestId: job.RequestId,
+		Message:   "Successfully deleted",
+	})
+
+	apiResponse.Body = string(responseBody)
+	apiResponse.StatusCode = http.StatusOK
+	return apiResponse, nil
+}
+
+func (h *Handler) ValidateVaultId(vaultId string) bool {
+	for _, validVaultId := range h.cfg.ValidVaultIds {
+		if vaultId == validVaultId {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) requiredRolesForRequest(request events.APIGatewayProxyRequest) []string {
+	switch {
+	case request.HTTPMethod == "GET":
+		return h.cfg.RequiredRolesGet
+	case request.HTTPMethod == "DELETE":
+		return h.cfg.RequiredRolesDelete
+	case request.HTTPMethod == "POST" && strings.HasSuffix(request.Path, ":cancel"):
+		return h.cfg.RequiredRolesDelete
+	case request.HTTPMethod == "POST" && strings.HasSuffix(request.Path, ":retry"):
+		return h.cfg.RequiredRolesRetry
+	default:
+		return nil
+	}
+}
+
+// ValidateAuthScheme reports whether token uses the Bearer auth scheme.
+func ValidateAuthScheme(token string) bool {
+	return strings.Split(token, " ")[0] == "Bearer"
+}
+
+func authErrorMessage(err error) string {
+	switch err {
+	case auth.ErrExpiredToken:
+		return "expired"
+	case auth.ErrBadSignature:
+		return "bad signature"
+	case auth.ErrUnknownKid:
+		return "unknown kid"
+	case auth.ErrWrongIssuer:
+		return "wrong issuer"
+	case auth.ErrWrongAudience:
+		return "wrong audience"
+	default:
+		return fmt.Sprintf("failed to verify token: %v", err)
+	}
+}
@@ -0,0 +1,757 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/golang-jwt/jwt"
+	log "github.com/sirupsen/logrus"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/auth"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/emr"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/logs"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/repository"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/skyflow"
+)
+
+// stubObjectStoreS3 is a minimal s3iface.S3API covering only the list, head
+// and get calls HandleGetLogs makes and the listing call HandleGetOutput
+// makes (not its presign request), backed by an in-memory
+// object store. Embedding the interface means any method we don't override
+// panics on use rather than failing to compile.
+type stubObjectStoreS3 struct {
+	s3iface.S3API
+	keys    []string
+	content map[string]string
+}
+
+func (s *stubObjectStoreS3) ListObjectsV2PagesWithContext(ctx aws.Context, input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, opts ...request.Option) error {
+	var objs []*s3.Object
+	for _, k := range s.keys {
+		key := k
+		objs = append(objs, &s3.Object{Key: &key})
+	}
+	fn(&s3.ListObjectsV2Output{Contents: objs}, true)
+	return nil
+}
+
+func (s *stubObjectStoreS3) HeadObjectWithContext(ctx aws.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error) {
+	body := s.content[aws.StringValue(input.Key)]
+	size := int64(len(body))
+	return &s3.HeadObjectOutput{ContentLength: &size}, nil
+}
+
+func (s *stubObjectStoreS3) GetObjectWithContext(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+	body := s.content[aws.StringValue(input.Key)]
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+var errBoom = errors.New("boom")
+
+func noopLogger() *log.Entry {
+	return log.NewEntry(log.New())
+}
+
+// mockEMR is a test double for emr.Client, configurable per test.
+type mockEMR struct {
+	cancelErr error
+	startErr  error
+}
+
+func (m *mockEMR) CancelJobRun(ctx context.Context, applicationId string, jobRunId string) error {
+	return m.cancelErr
+}
+
+func (m *mockEMR) StartJobRun(ctx context.Context, input emr.StartJobRunInput) (emr.StartJobRunOutput, error) {
+	if m.startErr != nil {
+		return emr.StartJobRunOutput{}, m.startErr
+	}
+	return emr.StartJobRunOutput{JobRunId: "new-jr-1"}, nil
+}
+
+type jwkDoc struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwksDoc struct {
+	Keys []jwkDoc `json:"keys"`
+}
+
+func newFakeJWKSServer(t *testing.T, key *rsa.PrivateKey, kid string) *httptest.Server {
+	t.Helper()
+
+	e := key.PublicKey.E
+	eb := []byte{byte(e >> 16), byte(e >> 8), byte(e)}
+	for len(eb) > 1 && eb[0] == 0 {
+		eb = eb[1:]
+	}
+
+	doc := jwksDoc{Keys: []jwkDoc{{
+		Kty: "RSA",
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(eb),
+	}}}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_ = json.NewEncoder(w).Encode(doc)
+	}))
+}
+
+func signedToken(t *testing.T, key *rsa.PrivateKey, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"jti": "jti-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func okSkyflow(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestHandleRequest_BadAuthScheme(t *testing.T) {
+	h := NewHandler(Config{Source: "JobManagementAPI"})
+
+	resp, err := h.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:     "GET",
+		PathParameters: map[string]string{"jobID": "", "vaultID": "vault-1"},
+		Headers:        map[string]string{"Authorization": "Basic abc"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandleRequest_InvalidVaultId(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksServer := newFakeJWKSServer(t, key, "kid-1")
+	defer jwksServer.Close()
+
+	h := NewHandler(Config{
+		Verifier:      auth.NewVerifier(jwksServer.URL, "", ""),
+		ValidVaultIds: []string{"vault-1"},
+		Source:        "JobManagementAPI",
+	})
+
+	token := signedToken(t, key, "kid-1")
+	resp, err := h.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:     "GET",
+		PathParameters: map[string]string{"jobID": "", "vaultID": "not-a-vault"},
+		Headers:        map[string]string{"Authorization": "Bearer " + token},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for invalid vault id, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandleRequest_SkyflowNonOKIsPassedThrough(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksServer := newFakeJWKSServer(t, key, "kid-1")
+	defer jwksServer.Close()
+
+	skyflowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("forbidden by skyflow"))
+	}))
+	defer skyflowServer.Close()
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	h := NewHandler(Config{
+		Verifier:      auth.NewVerifier(jwksServer.URL, "", ""),
+		Skyflow:       skyflow.NewClient(skyflowServer.URL),
+		Jobs:          repository.NewJobRepository(db),
+		EMR:           &mockEMR{},
+		ValidVaultIds: []string{"vault-1"},
+		Source:        "JobManagementAPI",
+	})
+
+	token := signedToken(t, key, "kid-1")
+	resp, err := h.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:     "GET",
+		PathParameters: map[string]string{"jobID": "", "vaultID": "vault-1"},
+		Headers:        map[string]string{"Authorization": "Bearer " + token},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected skyflow's 403 to pass through, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandleRequest_JobNotFound(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksServer := newFakeJWKSServer(t, key, "kid-1")
+	defer jwksServer.Close()
+
+	skyflowServer := httptest.NewServer(http.HandlerFunc(okSkyflow))
+	defer skyflowServer.Close()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM emr_job_details WHERE id=\\$1").
+		WithArgs("missing-job").
+		WillReturnError(errBoom)
+
+	h := NewHandler(Config{
+		Verifier:      auth.NewVerifier(jwksServer.URL, "", ""),
+		Skyflow:       skyflow.NewClient(skyflowServer.URL),
+		Jobs:          repository.NewJobRepository(db),
+		EMR:           &mockEMR{},
+		ValidVaultIds: []string{"vault-1"},
+		Source:        "JobManagementAPI",
+	})
+
+	token := signedToken(t, key, "kid-1")
+	resp, err := h.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:     "GET",
+		PathParameters: map[string]string{"jobID": "missing-job", "vaultID": "vault-1"},
+		Headers:        map[string]string{"Authorization": "Bearer " + token},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for missing job, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandleCancelJob_AlreadyCompleted(t *testing.T) {
+	h := &Handler{cfg: Config{EMR: &mockEMR{}}, logger: noopLogger()}
+
+	resp, err := h.HandleCancelJob(context.Background(), repository.Job{Id: "job-1", JobId: "jr-1", JobStatus: "SUCCESS"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandleCancelJob_AlreadyCancelling(t *testing.T) {
+	h := &Handler{cfg: Config{EMR: &mockEMR{}}, logger: noopLogger()}
+
+	resp, err := h.HandleCancelJob(context.Background(), repository.Job{Id: "job-1", JobId: "jr-1", JobStatus: "CANCELLING"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandleCancelJob_CancelFailure(t *testing.T) {
+	h := &Handler{cfg: Config{EMR: &mockEMR{cancelErr: errBoom}}, logger: noopLogger()}
+
+	resp, err := h.HandleCancelJob(context.Background(), repository.Job{Id: "job-1", JobId: "jr-1", JobStatus: "RUNNING"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 on cancel failure, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandleCancelJob_Success(t *testing.T) {
+	h := &Handler{cfg: Config{EMR: &mockEMR{}}, logger: noopLogger()}
+
+	resp, err := h.HandleCancelJob(context.Background(), repository.Job{Id: "job-1", JobId: "jr-1", JobStatus: "RUNNING"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %v", resp.StatusCode)
+	}
+}
+
+func TestValidateAuthScheme(t *testing.T) {
+	if !ValidateAuthScheme("Bearer token") {
+		t.Fatalf("expected Bearer scheme to be valid")
+	}
+	if ValidateAuthScheme("Basic abc") {
+		t.Fatalf("expected Basic scheme to be rejected")
+	}
+}
+
+func TestValidateVaultId(t *testing.T) {
+	h := &Handler{cfg: Config{ValidVaultIds: []string{"vault-1", "vault-2"}}}
+
+	if !h.ValidateVaultId("vault-2") {
+		t.Fatalf("expected vault-2 to be valid")
+	}
+	if h.ValidateVaultId("vault-3") {
+		t.Fatalf("expected vault-3 to be rejected")
+	}
+}
+
+func TestHandleRequest_MissingRole(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksServer := newFakeJWKSServer(t, key, "kid-1")
+	defer jwksServer.Close()
+
+	h := NewHandler(Config{
+		Verifier:         auth.NewVerifier(jwksServer.URL, "", ""),
+		ValidVaultIds:    []string{"vault-1"},
+		RequiredRolesGet: []string{"job:read"},
+		Source:           "JobManagementAPI",
+	})
+
+	token := signedToken(t, key, "kid-1")
+	resp, err := h.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:     "GET",
+		PathParameters: map[string]string{"jobID": "", "vaultID": "vault-1"},
+		Headers:        map[string]string{"Authorization": "Bearer " + token},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for missing role, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandleListJobs_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "jobid", "jobstatus", "requestid", "query", "destination", "jti", "cross_bucket_region", "created_at"}).
+		AddRow("job-1", "jr-1", "SUCCESS", "req-1", "select 1", "bucket/a", "jti-1", "us-east-1", time.Now())
+
+	mock.ExpectQuery("SELECT (.+) FROM emr_job_details").
+		WithArgs("jti-1", 51).
+		WillReturnRows(rows)
+
+	h := &Handler{cfg: Config{Jobs: repository.NewJobRepository(db)}, logger: noopLogger()}
+
+	resp, err := h.HandleListJobs(context.Background(), events.APIGatewayProxyRequest{}, &auth.Claims{Jti: "jti-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %v", resp.StatusCode)
+	}
+
+	var page repository.Page
+	if err := json.Unmarshal([]byte(resp.Body), &page); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0].JobId != "jr-1" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+}
+
+func TestHandleListJobs_RepositoryError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM emr_job_details").
+		WithArgs("jti-1", 51).
+		WillReturnError(errBoom)
+
+	h := &Handler{cfg: Config{Jobs: repository.NewJobRepository(db)}, logger: noopLogger()}
+
+	resp, err := h.HandleListJobs(context.Background(), events.APIGatewayProxyRequest{}, &auth.Claims{Jti: "jti-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandleBulkCancel_EmptyIds(t *testing.T) {
+	h := &Handler{cfg: Config{}, logger: noopLogger()}
+
+	resp, err := h.HandleBulkCancel(context.Background(), events.APIGatewayProxyRequest{Body: `{"ids":[]}`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandleBulkCancel_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM emr_job_details WHERE id=\\$1").
+		WithArgs("job-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "jobid", "jobstatus", "requestid", "query", "destination", "jti", "cross_bucket_region"}).
+			AddRow("job-1", "jr-1", "RUNNING", "req-1", "select 1", "bucket/a", "jti-1", "us-east-1"))
+
+	h := &Handler{cfg: Config{Jobs: repository.NewJobRepository(db), EMR: &mockEMR{}}, logger: noopLogger()}
+
+	resp, err := h.HandleBulkCancel(context.Background(), events.APIGatewayProxyRequest{Body: `{"ids":["job-1"]}`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %v", resp.StatusCode)
+	}
+
+	var result BulkCancelResponse
+	if err := json.Unmarshal([]byte(resp.Body), &result); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(result.Cancelled) != 1 || len(result.Failed) != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestHandleGetLogs_NotConfigured(t *testing.T) {
+	h := &Handler{cfg: Config{}, logger: noopLogger()}
+
+	resp, err := h.HandleGetLogs(context.Background(), events.APIGatewayProxyRequest{}, repository.Job{Id: "job-1", JobId: "jr-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandleGetLogs_BacklogOnly(t *testing.T) {
+	stub := &stubObjectStoreS3{
+		keys: []string{"applications/app-1/jobs/jr-1/stdout"},
+		content: map[string]string{
+			"applications/app-1/jobs/jr-1/stdout": "line1\nline2\n",
+		},
+	}
+
+	h := &Handler{
+		cfg:    Config{Logs: logs.NewSource(stub, "bucket"), ApplicationId: "app-1"},
+		logger: noopLogger(),
+	}
+
+	resp, err := h.HandleGetLogs(context.Background(), events.APIGatewayProxyRequest{}, repository.Job{Id: "job-1", JobId: "jr-1", JobStatus: "RUNNING"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %v", resp.StatusCode)
+	}
+	if resp.Body != "line1\nline2\n" {
+		t.Fatalf("unexpected body: %q", resp.Body)
+	}
+}
+
+func TestHandleGetOutput_NotYetSuccessful(t *testing.T) {
+	h := &Handler{logger: noopLogger()}
+
+	resp, err := h.HandleGetOutput(context.Background(), events.APIGatewayProxyRequest{}, repository.Job{Id: "job-1", JobStatus: "RUNNING"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandleGetOutput_NoObjectsFound(t *testing.T) {
+	h := &Handler{
+		cfg: Config{
+			NewOutputS3: func(region string) (s3iface.S3API, error) {
+				return &stubObjectStoreS3{}, nil
+			},
+		},
+		logger: noopLogger(),
+	}
+
+	resp, err := h.HandleGetOutput(context.Background(), events.APIGatewayProxyRequest{}, repository.Job{Id: "job-1", JobStatus: "SUCCESS", Destination: "bucket/prefix"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %v", resp.StatusCode)
+	}
+}
+
+func TestPresignTTL_ClampsToConfiguredMax(t *testing.T) {
+	h := &Handler{cfg: Config{PresignMaxTTL: time.Minute}}
+
+	if ttl := h.presignTTL("3600"); ttl != time.Minute {
+		t.Fatalf("expected caller-requested ttl to be clamped to the configured max, got %v", ttl)
+	}
+}
+
+func TestPresignTTL_UnderMaxIsUnchanged(t *testing.T) {
+	h := &Handler{cfg: Config{PresignMaxTTL: time.Hour}}
+
+	if ttl := h.presignTTL("60"); ttl != time.Minute {
+		t.Fatalf("expected a requested ttl under the max to pass through, got %v", ttl)
+	}
+}
+
+func TestPresignTTL_DefaultsWhenUnset(t *testing.T) {
+	h := &Handler{cfg: Config{}}
+
+	if ttl := h.presignTTL(""); ttl != defaultPresignTTL {
+		t.Fatalf("expected defaultPresignTTL when no ttl param is given, got %v", ttl)
+	}
+}
+
+func TestPresignTTL_HugeValueClampsInsteadOfOverflowing(t *testing.T) {
+	h := &Handler{cfg: Config{PresignMaxTTL: time.Hour}}
+
+	if ttl := h.presignTTL("9223372037"); ttl != time.Hour {
+		t.Fatalf("expected an overflow-sized ttl to clamp to the configured max, got %v", ttl)
+	}
+}
+
+func TestHandleRetryJob_NotRetryable(t *testing.T) {
+	h := &Handler{logger: noopLogger()}
+
+	resp, err := h.HandleRetryJob(context.Background(), repository.Job{Id: "job-1", JobStatus: "RUNNING"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandleRetryJob_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO emr_job_details").
+		WithArgs("new-jr-1", "SUBMITTED", "retry-req-1", "select 1", "bucket/a", "jti-1", "us-east-1", "job-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("job-2"))
+
+	h := &Handler{
+		cfg:    Config{Jobs: repository.NewJobRepository(db), EMR: &mockEMR{}},
+		logger: noopLogger(),
+	}
+
+	job := repository.Job{
+		Id: "job-1", JobId: "jr-1", JobStatus: "FAILURE", RequestId: "req-1",
+		Query: "select 1", Destination: "bucket/a", Jti: "jti-1", Region: "us-east-1",
+	}
+
+	resp, err := h.HandleRetryJob(context.Background(), job, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %v", resp.StatusCode)
+	}
+
+	var result RetryResponse
+	if err := json.Unmarshal([]byte(resp.Body), &result); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if result.Id != "job-2" || result.JobId != "new-jr-1" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestHandleRetryJob_IdempotencyRaceLoserReturnsWinnersResult(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO idempotency").
+		WithArgs("key-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectQuery("SELECT new_id, new_job_id FROM idempotency").
+		WithArgs("key-1").
+		WillReturnRows(sqlmock.NewRows([]string{"new_id", "new_job_id"}).AddRow("job-2", "jr-2"))
+
+	h := &Handler{
+		cfg:    Config{Jobs: repository.NewJobRepository(db), EMR: &mockEMR{}},
+		logger: noopLogger(),
+	}
+
+	job := repository.Job{Id: "job-1", JobId: "jr-1", JobStatus: "FAILURE"}
+
+	resp, err := h.HandleRetryJob(context.Background(), job, "key-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %v", resp.StatusCode)
+	}
+
+	var result RetryResponse
+	if err := json.Unmarshal([]byte(resp.Body), &result); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if result.Id != "job-2" || result.JobId != "jr-2" {
+		t.Fatalf("expected the reservation winner's result to be returned, got %+v", result)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestHandleRetryJob_InsertRetryFailureFinalizesIdempotencyKeyWithStartedJobRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO idempotency").
+		WithArgs("key-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO emr_job_details").
+		WillReturnError(errBoom)
+	mock.ExpectExec("UPDATE idempotency SET new_id").
+		WithArgs("key-1", "", "new-jr-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	h := &Handler{
+		cfg:    Config{Jobs: repository.NewJobRepository(db), EMR: &mockEMR{}},
+		logger: noopLogger(),
+	}
+
+	job := repository.Job{Id: "job-1", JobId: "jr-1", JobStatus: "FAILURE"}
+
+	resp, err := h.HandleRetryJob(context.Background(), job, "key-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %v", resp.StatusCode)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expected the reservation to be finalized with the started job run, not left claimed: %v", err)
+	}
+}
+
+func TestHandleRetryJob_RetryAfterInsertRetryFailureReturnsStartedJobRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO idempotency").
+		WithArgs("key-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT new_id, new_job_id FROM idempotency").
+		WithArgs("key-1").
+		WillReturnRows(sqlmock.NewRows([]string{"new_id", "new_job_id"}).AddRow("", "new-jr-1"))
+
+	h := &Handler{
+		cfg:    Config{Jobs: repository.NewJobRepository(db), EMR: &mockEMR{}},
+		logger: noopLogger(),
+	}
+
+	job := repository.Job{Id: "job-1", JobId: "jr-1", JobStatus: "FAILURE"}
+
+	resp, err := h.HandleRetryJob(context.Background(), job, "key-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the retry that previously only half-completed to resolve as 200, got %v", resp.StatusCode)
+	}
+
+	var result RetryResponse
+	if err := json.Unmarshal([]byte(resp.Body), &result); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if result.JobId != "new-jr-1" {
+		t.Fatalf("expected the already-started job run to be returned instead of starting a duplicate, got %+v", result)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestHandleRetryJob_StartFailureReleasesIdempotencyKeyForRetry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO idempotency").
+		WithArgs("key-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM idempotency").
+		WithArgs("key-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	h := &Handler{
+		cfg:    Config{Jobs: repository.NewJobRepository(db), EMR: &mockEMR{startErr: errBoom}},
+		logger: noopLogger(),
+	}
+
+	job := repository.Job{Id: "job-1", JobId: "jr-1", JobStatus: "FAILURE"}
+
+	resp, err := h.HandleRetryJob(context.Background(), job, "key-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %v", resp.StatusCode)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expected the reservation to be released after StartJobRun failed: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/auth"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/repository"
+)
+
+// HandleListJobs serves the paginated job listing/search endpoint.
+func (h *Handler) HandleListJobs(ctx context.Context, request events.APIGatewayProxyRequest, claims *auth.Claims) (events.APIGatewayProxyResponse, error) {
+	apiResponse := events.APIGatewayProxyResponse{}
+
+	filter := repository.Filter{
+		Jti:         claims.Jti,
+		JobStatus:   request.QueryStringParameters["jobStatus"],
+		Destination: request.QueryStringParameters["destination"],
+		Cursor:      request.QueryStringParameters["cursor"],
+	}
+
+	if limitParam := request.QueryStringParameters["limit"]; limitParam != "" {
+		if limit, err := strconv.Atoi(limitParam); err == nil {
+			filter.Limit = limit
+		}
+	}
+
+	if fromParam := request.QueryStringParameters["from"]; fromParam != "" {
+		if from, err := time.Parse(time.RFC3339, fromParam); err == nil {
+			filter.From = from
+		}
+	}
+
+	if toParam := request.QueryStringParameters["to"]; toParam != "" {
+		if to, err := time.Parse(time.RFC3339, toParam); err == nil {
+			filter.To = to
+		}
+	}
+
+	h.logger.Info("Listing jobs")
+
+	page, err := h.cfg.Jobs.List(ctx, filter)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("Failed to list jobs with error: %v", err.Error()))
+
+		responseBody, _ := json.Marshal(FailureResponse{
+			Message: fmt.Sprintf("Failed to list jobs with error: %v", err.Error()),
+		})
+
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusBadRequest
+		return apiResponse, nil
+	}
+
+	h.logger.Info(fmt.Sprintf("Successfully listed %v jobs", len(page.Items)))
+
+	responseBody, _ := json.Marshal(page)
+
+	apiResponse.Body = string(responseBody)
+	apiResponse.StatusCode = http.StatusOK
+	return apiResponse, nil
+}
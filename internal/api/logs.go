@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/repository"
+)
+
+// HandleGetLogs serves a job's driver/executor log backlog. With
+// ?follow=true, the handler additionally polls for new lines and blocks,
+// up to the Lambda's remaining time budget, until the job reaches a
+// terminal status, then returns everything it collected as one response.
+//
+// This is a bounded backlog-then-wait batch call, not incremental push
+// streaming: API Gateway invokes this Lambda via the buffered invoke model
+// (lambda.Start), so nothing reaches the client until HandleRequest
+// returns. A caller that wants a live tail must poll this endpoint itself
+// (e.g. resuming from the last seen line) rather than expect a long-lived
+// connection.
+func (h *Handler) HandleGetLogs(ctx context.Context, request events.APIGatewayProxyRequest, job repository.Job) (events.APIGatewayProxyResponse, error) {
+	apiResponse := events.APIGatewayProxyResponse{}
+
+	if h.cfg.Logs == nil {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      job.Id,
+			Message: "Log streaming is not configured",
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusServiceUnavailable
+		return apiResponse, nil
+	}
+
+	follow := request.QueryStringParameters["follow"] == "true"
+
+	lines := defaultLogBacklogLines
+	if linesParam := request.QueryStringParameters["lines"]; linesParam != "" {
+		if parsed, err := strconv.Atoi(linesParam); err == nil && parsed > 0 {
+			lines = parsed
+		}
+	}
+
+	prefix := h.jobLogPrefix(job.JobId)
+
+	backlog, offsets, err := h.cfg.Logs.Backlog(ctx, prefix, lines)
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("Failed to read log backlog for jobId: %v with error: %v", job.JobId, err.Error()))
+
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      job.Id,
+			Message: fmt.Sprintf("Failed to read logs: %v", err.Error()),
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusBadGateway
+		return apiResponse, nil
+	}
+
+	var body strings.Builder
+	for _, line := range backlog {
+		body.WriteString(line.Text)
+		body.WriteString("\n")
+	}
+
+	if !follow {
+		apiResponse.Headers = map[string]string{"Content-Type": "text/plain"}
+		apiResponse.Body = body.String()
+		apiResponse.StatusCode = http.StatusOK
+		return apiResponse, nil
+	}
+
+	deadline := time.Now().Add(logFollowBudget(ctx))
+	finalStatus := job.JobStatus
+
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		latest, err := h.cfg.Jobs.Get(ctx, job.Id)
+		if err != nil {
+			break
+		}
+
+		newLines, next, err := h.cfg.Logs.Tail(ctx, prefix, offsets)
+		if err != nil {
+			h.logger.Error(fmt.Sprintf("Failed to tail logs for jobId: %v with error: %v", job.JobId, err.Error()))
+			break
+		}
+		offsets = next
+
+		for _, line := range newLines {
+			body.WriteString(line.Text)
+			body.WriteString("\n")
+		}
+
+		finalStatus = latest.JobStatus
+		if isTerminalJobStatus(latest.JobStatus) {
+			break
+		}
+
+		time.Sleep(logPollInterval)
+	}
+
+	body.WriteString(fmt.Sprintf("--- status: %v ---\n", finalStatus))
+
+	apiResponse.Headers = map[string]string{"Content-Type": "text/plain"}
+	apiResponse.Body = body.String()
+	apiResponse.StatusCode = http.StatusOK
+	return apiResponse, nil
+}
+
+func (h *Handler) jobLogPrefix(jobId string) string {
+	prefix := h.cfg.LogPrefix
+	if prefix != "" {
+		prefix = strings.TrimSuffix(prefix, "/") + "/"
+	}
+	return fmt.Sprintf("%sapplications/%s/jobs/%s/", prefix, h.cfg.ApplicationId, jobId)
+}
+
+func isTerminalJobStatus(status string) bool {
+	switch status {
+	case "SUCCESS", "FAILURE", "CANCELLED":
+		return true
+	default:
+		return false
+	}
+}
+
+func logFollowBudget(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining > logFollowSafetyMargin {
+			return remaining - logFollowSafetyMargin
+		}
+	}
+	return 30 * time.Second
+}
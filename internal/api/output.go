@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/repository"
+)
+
+// JobOutputResponse lists presigned download URLs for a completed job's
+// output objects.
+type JobOutputResponse struct {
+	Urls []string `json:"urls"`
+}
+
+// HandleGetOutput presigns download URLs for a completed job's output
+// objects, optionally redirecting straight to the object when there is
+// exactly one and ?stream=true is set.
+func (h *Handler) HandleGetOutput(ctx context.Context, request events.APIGatewayProxyRequest, job repository.Job) (events.APIGatewayProxyResponse, error) {
+	apiResponse := events.APIGatewayProxyResponse{}
+
+	if job.JobStatus != "SUCCESS" {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      job.Id,
+			Message: fmt.Sprintf("Job for jobId:%v is not yet successful", job.JobId),
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusBadRequest
+		return apiResponse, nil
+	}
+
+	bucket, prefix := splitDestination(job.Destination)
+
+	outputS3, err := h.cfg.NewOutputS3(job.Region)
+	if err != nil {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      job.Id,
+			Message: fmt.Sprintf("Failed to create session for region %v: %v", job.Region, err.Error()),
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusInternalServerError
+		return apiResponse, nil
+	}
+
+	var keys []string
+	err = outputS3.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			keys = append(keys, aws.StringValue(obj.Key))
+		}
+		return true
+	})
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("Failed to list output objects for jobId: %v with error: %v", job.JobId, err.Error()))
+
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      job.Id,
+			Message: fmt.Sprintf("Failed to list output objects: %v", err.Error()),
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusBadGateway
+		return apiResponse, nil
+	}
+
+	if len(keys) == 0 {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      job.Id,
+			Message: fmt.Sprintf("No output objects found under %v", job.Destination),
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusNotFound
+		return apiResponse, nil
+	}
+
+	ttl := h.presignTTL(request.QueryStringParameters["ttl"])
+
+	urls := make([]string, 0, len(keys))
+	for _, key := range keys {
+		presignReq, _ := outputS3.GetObjectRequest(&s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+
+		url, err := presignReq.Presign(ttl)
+		if err != nil {
+			h.logger.Error(fmt.Sprintf("Failed to presign object %v for jobId: %v with error: %v", key, job.JobId, err.Error()))
+
+			responseBody, _ := json.Marshal(FailureResponse{
+				Id:      job.Id,
+				Message: fmt.Sprintf("Failed to presign object %v: %v", key, err.Error()),
+			})
+			apiResponse.Body = string(responseBody)
+			apiResponse.StatusCode = http.StatusInternalServerError
+			return apiResponse, nil
+		}
+
+		urls = append(urls, url)
+	}
+
+	h.logger.WithFields(log.Fields{
+		"presignedObjectKeys": keys,
+		"presignExpiresAt":    time.Now().Add(ttl),
+	}).Info("Generated presigned output URLs")
+
+	if request.QueryStringParameters["stream"] == "true" && len(urls) == 1 {
+		apiResponse.Headers = map[string]string{"Location": urls[0]}
+		apiResponse.StatusCode = http.StatusFound
+		return apiResponse, nil
+	}
+
+	responseBody, _ := json.Marshal(JobOutputResponse{Urls: urls})
+	apiResponse.Body = string(responseBody)
+	apiResponse.StatusCode = http.StatusOK
+	return apiResponse, nil
+}
+
+func splitDestination(destination string) (string, string) {
+	parts := strings.SplitN(destination, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// presignTTL resolves the caller-requested ?ttl= into a duration, clamped to
+// h.cfg.PresignMaxTTL (defaultPresignMaxTTL if unset) so a caller can't mint
+// a presigned URL valid far longer than the operator intends.
+func (h *Handler) presignTTL(ttlParam string) time.Duration {
+	maxTTL := h.cfg.PresignMaxTTL
+	if maxTTL <= 0 {
+		maxTTL = defaultPresignMaxTTL
+	}
+
+	ttl := defaultPresignTTL
+	if seconds, err := strconv.Atoi(ttlParam); err == nil && seconds > 0 {
+		// Compare against maxTTL in seconds before converting to avoid
+		// overflowing time.Duration (nanoseconds) for very large inputs,
+		// which would wrap to a negative duration and bypass the clamp
+		// below.
+		if time.Duration(seconds) > maxTTL/time.Second {
+			return maxTTL
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+
+	if ttl > maxTTL {
+		return maxTTL
+	}
+	return ttl
+}
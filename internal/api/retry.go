@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/emr"
+	"github.com/ksilawat-deqode/JobManagementAPI/internal/repository"
+)
+
+// RetryResponse identifies the new job run created by a retry.
+type RetryResponse struct {
+	Id    string `json:"id"`
+	JobId string `json:"jobId"`
+}
+
+// HandleRetryJob resubmits a failed or cancelled job as a new job run. When
+// idempotencyKey is set, it atomically reserves the key before resubmitting
+// so that concurrent retries with the same key can't both start a job run;
+// the loser waits briefly for the winner's result and returns it instead of
+// submitting a duplicate. If the reservation itself fails with an error, the
+// error is logged and the retry proceeds without that protection, so
+// concurrent retries with the same key may then both start a job run. Every
+// error path after a successful reservation
+// resolves it rather than leaving it claimed-but-unfinalized for the rest
+// of idempotencyTTL: if StartJobRun fails before any job run exists, the
+// reservation is released so the same key can be retried immediately; if
+// InsertRetry fails after a job run has already started, the reservation
+// is finalized with that job run's id instead of released, since releasing
+// it could let a retry re-run StartJobRun and start a second, duplicate
+// job run for work that's already in flight.
+func (h *Handler) HandleRetryJob(ctx context.Context, job repository.Job, idempotencyKey string) (events.APIGatewayProxyResponse, error) {
+	apiResponse := events.APIGatewayProxyResponse{}
+
+	if job.JobStatus != "FAILURE" && job.JobStatus != "CANCELLED" {
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      job.Id,
+			Message: fmt.Sprintf("Job for jobId:%v is not retryable from status %v", job.JobId, job.JobStatus),
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusBadRequest
+		return apiResponse, nil
+	}
+
+	if idempotencyKey != "" {
+		reserved, err := h.cfg.Jobs.ReserveIdempotencyKey(ctx, idempotencyKey, idempotencyTTL)
+		if err != nil {
+			h.logger.Error(fmt.Sprintf("Failed to reserve idempotency key with error: %v", err.Error()))
+		} else if !reserved {
+			existing, found, err := h.awaitIdempotencyResult(ctx, idempotencyKey)
+			if err != nil {
+				h.logger.Error(fmt.Sprintf("Failed to look up idempotency key with error: %v", err.Error()))
+			} else if found {
+				h.logger.Info(fmt.Sprintf("Returning existing retry for idempotency key %v", idempotencyKey))
+
+				responseBody, _ := json.Marshal(RetryResponse{Id: existing.Id, JobId: existing.JobId})
+				apiResponse.Body = string(responseBody)
+				apiResponse.StatusCode = http.StatusOK
+				return apiResponse, nil
+			}
+
+			responseBody, _ := json.Marshal(FailureResponse{
+				Id:      job.Id,
+				Message: "A retry for this idempotency key is already in progress, try again shortly",
+			})
+			apiResponse.Body = string(responseBody)
+			apiResponse.StatusCode = http.StatusConflict
+			return apiResponse, nil
+		}
+	}
+
+	h.logger.Info(fmt.Sprintf("Resubmitting jobId: %v", job.JobId))
+
+	startOutput, err := h.cfg.EMR.StartJobRun(ctx, emr.StartJobRunInput{
+		ApplicationId:       h.cfg.ApplicationId,
+		ExecutionRoleArn:    h.cfg.ExecutionRoleArn,
+		Name:                fmt.Sprintf("retry-%v", job.JobId),
+		SparkEntryPoint:     h.cfg.SparkEntryPointJar,
+		SparkEntryPointArgs: []string{"--query", job.Query, "--destination", job.Destination},
+	})
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("Failed to resubmit jobId: %v with error: %v", job.JobId, err.Error()))
+		h.releaseIdempotencyKey(ctx, idempotencyKey)
+
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      job.Id,
+			Message: fmt.Sprintf("Failed to resubmit jobId: %v with error: %v", job.JobId, err.Error()),
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusBadRequest
+		return apiResponse, nil
+	}
+
+	retryResponse := RetryResponse{JobId: startOutput.JobRunId}
+
+	retryResponse.Id, err = h.cfg.Jobs.InsertRetry(ctx, job, retryResponse.JobId, fmt.Sprintf("retry-%v", job.RequestId))
+	if err != nil {
+		h.logger.Error(fmt.Sprintf("Failed to persist retry row for jobId: %v with error: %v", job.JobId, err.Error()))
+		// The EMR job run already started successfully above, so the
+		// reservation is finalized with the job run id we do have rather
+		// than released: releasing it would let a client retry re-run
+		// StartJobRun and start a second, duplicate job run for work
+		// that's already in flight. Finalizing still unblocks later
+		// retries with this key, which will now see the started job run
+		// instead of polling to a 409 for the rest of idempotencyTTL.
+		h.finalizeIdempotencyKey(ctx, idempotencyKey, repository.RetryResult{JobId: retryResponse.JobId})
+
+		responseBody, _ := json.Marshal(FailureResponse{
+			Id:      job.Id,
+			Message: fmt.Sprintf("Failed to persist retry row: %v", err.Error()),
+		})
+		apiResponse.Body = string(responseBody)
+		apiResponse.StatusCode = http.StatusInternalServerError
+		return apiResponse, nil
+	}
+
+	h.finalizeIdempotencyKey(ctx, idempotencyKey, repository.RetryResult{Id: retryResponse.Id, JobId: retryResponse.JobId})
+
+	h.logger.Info(fmt.Sprintf("Successfully resubmitted jobId: %v as new jobId: %v", job.JobId, retryResponse.JobId))
+
+	responseBody, _ := json.Marshal(retryResponse)
+	apiResponse.Body = string(responseBody)
+	apiResponse.StatusCode = http.StatusOK
+	return apiResponse, nil
+}
+
+// releaseIdempotencyKey frees a reservation won via ReserveIdempotencyKey
+// after the retry it was guarding failed, so the key doesn't stay claimed
+// for the rest of its TTL. It is a no-op when idempotencyKey is empty.
+func (h *Handler) releaseIdempotencyKey(ctx context.Context, idempotencyKey string) {
+	if idempotencyKey == "" {
+		return
+	}
+	if err := h.cfg.Jobs.ReleaseIdempotencyKey(ctx, idempotencyKey); err != nil {
+		h.logger.Error(fmt.Sprintf("Failed to release idempotency key with error: %v", err.Error()))
+	}
+}
+
+// finalizeIdempotencyKey records result for a reservation won via
+// ReserveIdempotencyKey, making it visible to later callers via
+// LookupIdempotencyKey instead of leaving it claimed for the rest of its
+// TTL. It is a no-op when idempotencyKey is empty.
+func (h *Handler) finalizeIdempotencyKey(ctx context.Context, idempotencyKey string, result repository.RetryResult) {
+	if idempotencyKey == "" {
+		return
+	}
+	if err := h.cfg.Jobs.FinalizeIdempotencyKey(ctx, idempotencyKey, result); err != nil {
+		h.logger.Error(fmt.Sprintf("Failed to finalize idempotency key with error: %v", err.Error()))
+	}
+}
+
+// awaitIdempotencyResult polls for the result of a retry reserved by a
+// concurrent caller, up to idempotencyWaitBudget, since that caller may
+// still be mid-flight when we lose the reservation race.
+func (h *Handler) awaitIdempotencyResult(ctx context.Context, key string) (repository.RetryResult, bool, error) {
+	deadline := time.Now().Add(idempotencyWaitBudget)
+
+	for {
+		result, found, err := h.cfg.Jobs.LookupIdempotencyKey(ctx, key)
+		if err != nil || found {
+			return result, found, err
+		}
+
+		if !time.Now().Before(deadline) || ctx.Err() != nil {
+			return repository.RetryResult{}, false, nil
+		}
+
+		time.Sleep(idempotencyPollInterval)
+	}
+}
@@ -0,0 +1,316 @@
+// Package auth verifies bearer JWTs against a remote JWKS before the Lambda
+// trusts any claim (jti, subject, roles) carried on the token.
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Claims is the subset of verified JWT claims the API cares about.
+type Claims struct {
+	Jti   string
+	Sub   string
+	Roles []string
+}
+
+// Distinct, caller-facing verification failures so handlers can return a
+// specific message instead of a generic "unauthorized".
+var (
+	ErrExpiredToken   = errors.New("expired")
+	ErrBadSignature   = errors.New("bad signature")
+	ErrUnknownKid     = errors.New("unknown kid")
+	ErrWrongIssuer    = errors.New("wrong issuer")
+	ErrWrongAudience  = errors.New("wrong audience")
+	ErrUnsupportedAlg = errors.New("unsupported alg")
+	ErrMissingJti     = errors.New("missing jti claim")
+)
+
+var allowedAlgs = map[string]bool{"RS256": true, "ES256": true}
+
+const defaultCacheTTL = 5 * time.Minute
+
+// Verifier fetches and caches a JWKS document keyed by kid, and verifies
+// bearer tokens against it.
+type Verifier struct {
+	jwksURL          string
+	expectedIssuer   string
+	expectedAudience string
+	httpClient       *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	expiresAt time.Time
+}
+
+// NewVerifier builds a Verifier against an explicit JWKS endpoint.
+func NewVerifier(jwksURL, issuer, audience string) *Verifier {
+	return &Verifier{
+		jwksURL:          jwksURL,
+		expectedIssuer:   issuer,
+		expectedAudience: audience,
+		httpClient:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewVerifierFromEnv builds a Verifier from JWKS_URL, JWT_ISSUER and
+// JWT_AUDIENCE.
+func NewVerifierFromEnv() *Verifier {
+	return NewVerifier(os.Getenv("JWKS_URL"), os.Getenv("JWT_ISSUER"), os.Getenv("JWT_AUDIENCE"))
+}
+
+// ExtractJTI validates a "Bearer <token>" header end to end and returns the
+// jti claim from the verified token.
+func (v *Verifier) ExtractJTI(authHeader string) (string, error) {
+	claims, err := v.Verify(authHeader)
+	if err != nil {
+		return "", err
+	}
+	return claims.Jti, nil
+}
+
+// Verify validates a "Bearer <token>" header against the JWKS and returns the
+// verified claims this API relies on.
+func (v *Verifier) Verify(authHeader string) (*Claims, error) {
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 {
+		return nil, ErrBadSignature
+	}
+	tokenString := parts[1]
+
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		alg, _ := t.Header["alg"].(string)
+		if !allowedAlgs[alg] {
+			return nil, ErrUnsupportedAlg
+		}
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			return nil, ErrUnknownKid
+		}
+		return v.key(kid)
+	})
+	if err != nil {
+		return nil, classifyError(err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrBadSignature
+	}
+
+	if v.expectedIssuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.expectedIssuer {
+			return nil, ErrWrongIssuer
+		}
+	}
+
+	if v.expectedAudience != "" && !claims.VerifyAudience(v.expectedAudience, true) {
+		return nil, ErrWrongAudience
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil, ErrMissingJti
+	}
+
+	sub, _ := claims["sub"].(string)
+
+	return &Claims{
+		Jti:   jti,
+		Sub:   sub,
+		Roles: extractRoles(claims),
+	}, nil
+}
+
+// HasAllRoles reports whether the claims carry every role in required. An
+// empty required list is always satisfied.
+func (c *Claims) HasAllRoles(required []string) bool {
+	if len(required) == 0 {
+		return true
+	}
+
+	have := make(map[string]bool, len(c.Roles))
+	for _, r := range c.Roles {
+		have[r] = true
+	}
+
+	for _, r := range required {
+		if !have[r] {
+			return false
+		}
+	}
+	return true
+}
+
+func extractRoles(claims jwt.MapClaims) []string {
+	if raw, ok := claims["roles"].([]interface{}); ok {
+		roles := make([]string, 0, len(raw))
+		for _, r := range raw {
+			if s, ok := r.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+		return roles
+	}
+	if scope, ok := claims["scope"].(string); ok && scope != "" {
+		return strings.Fields(scope)
+	}
+	return nil
+}
+
+func classifyError(err error) error {
+	ve, ok := err.(*jwt.ValidationError)
+	if !ok {
+		return ErrBadSignature
+	}
+
+	switch {
+	case ve.Errors&(jwt.ValidationErrorExpired) != 0:
+		return ErrExpiredToken
+	case ve.Errors&(jwt.ValidationErrorNotValidYet) != 0:
+		return ErrExpiredToken
+	case errors.Is(ve.Inner, ErrUnknownKid):
+		return ErrUnknownKid
+	case errors.Is(ve.Inner, ErrUnsupportedAlg):
+		return ErrUnsupportedAlg
+	default:
+		return ErrBadSignature
+	}
+}
+
+// key returns the public key for kid, refreshing the cached JWKS document if
+// it is missing, stale, or doesn't (yet) contain kid.
+func (v *Verifier) key(kid string) (interface{}, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.keys != nil && time.Now().Before(v.expiresAt) {
+		if key, ok := v.keys[kid]; ok {
+			return key, nil
+		}
+	}
+
+	if err := v.refreshLocked(); err != nil {
+		return nil, err
+	}
+
+	key, ok := v.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKid
+	}
+	return key, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwksDocument struct {
+	Keys []jwk `json:"keys"`
+}
+
+func (v *Verifier) refreshLocked() error {
+	resp, err := v.httpClient.Get(v.jwksURL)
+	if err != nil {
+		return fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read jwks: %w", err)
+	}
+
+	var doc jwksDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("parse jwks: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(doc.Keys))
+	for _, k := range doc.Keys {
+		publicKey, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = publicKey
+	}
+
+	v.keys = keys
+	v.expiresAt = time.Now().Add(cacheTTL(resp.Header.Get("Cache-Control")))
+	return nil
+}
+
+func cacheTTL(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if !strings.HasPrefix(directive, "max-age=") {
+			continue
+		}
+		if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return defaultCacheTTL
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode n: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode e: %w", err)
+		}
+
+		exponent := 0
+		for _, b := range e {
+			exponent = exponent<<8 | int(b)
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: exponent,
+		}, nil
+	case "EC":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decode x: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("decode y: %w", err)
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
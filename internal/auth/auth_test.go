@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func newFakeJWKSServer(t *testing.T, key *rsa.PrivateKey, kid string) *httptest.Server {
+	t.Helper()
+
+	doc := jwksDocument{
+		Keys: []jwk{
+			{
+				Kty: "RSA",
+				Kid: kid,
+				N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(bigEndianExponent(key.PublicKey.E)),
+			},
+		},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_ = json.NewEncoder(w).Encode(doc)
+	}))
+}
+
+func bigEndianExponent(e int) []byte {
+	b := []byte{byte(e >> 16), byte(e >> 8), byte(e)}
+	for len(b) > 1 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign test token: %v", err)
+	}
+	return signed
+}
+
+func TestExtractJTI_ValidToken(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := newFakeJWKSServer(t, key, "kid-1")
+	defer server.Close()
+
+	verifier := NewVerifier(server.URL, "https://issuer.example", "job-api")
+
+	token := signToken(t, key, "kid-1", jwt.MapClaims{
+		"jti": "job-jti-123",
+		"sub": "user-1",
+		"iss": "https://issuer.example",
+		"aud": "job-api",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	jti, err := verifier.ExtractJTI("Bearer " + token)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if jti != "job-jti-123" {
+		t.Fatalf("expected jti %q, got %q", "job-jti-123", jti)
+	}
+}
+
+func TestVerify_ExpiredToken(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := newFakeJWKSServer(t, key, "kid-1")
+	defer server.Close()
+
+	verifier := NewVerifier(server.URL, "", "")
+
+	token := signToken(t, key, "kid-1", jwt.MapClaims{
+		"jti": "job-jti-123",
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	})
+
+	_, err := verifier.Verify("Bearer " + token)
+	if err != ErrExpiredToken {
+		t.Fatalf("expected ErrExpiredToken, got %v", err)
+	}
+}
+
+func TestVerify_UnknownKid(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := newFakeJWKSServer(t, key, "kid-1")
+	defer server.Close()
+
+	verifier := NewVerifier(server.URL, "", "")
+
+	token := signToken(t, key, "kid-does-not-exist", jwt.MapClaims{
+		"jti": "job-jti-123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	_, err := verifier.Verify("Bearer " + token)
+	if err != ErrUnknownKid {
+		t.Fatalf("expected ErrUnknownKid, got %v", err)
+	}
+}
+
+func TestVerify_BadSignature(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := newFakeJWKSServer(t, key, "kid-1")
+	defer server.Close()
+
+	verifier := NewVerifier(server.URL, "", "")
+
+	token := signToken(t, otherKey, "kid-1", jwt.MapClaims{
+		"jti": "job-jti-123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	_, err := verifier.Verify("Bearer " + token)
+	if err != ErrBadSignature {
+		t.Fatalf("expected ErrBadSignature, got %v", err)
+	}
+}
+
+func TestVerify_WrongIssuer(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := newFakeJWKSServer(t, key, "kid-1")
+	defer server.Close()
+
+	verifier := NewVerifier(server.URL, "https://expected.example", "")
+
+	token := signToken(t, key, "kid-1", jwt.MapClaims{
+		"jti": "job-jti-123",
+		"iss": "https://someone-else.example",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	_, err := verifier.Verify("Bearer " + token)
+	if err != ErrWrongIssuer {
+		t.Fatalf("expected ErrWrongIssuer, got %v", err)
+	}
+}
+
+func TestVerify_CachesKeysWithinTTL(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	fetches := 0
+
+	doc := jwksDocument{Keys: []jwk{{
+		Kty: "RSA",
+		Kid: "kid-1",
+		N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(bigEndianExponent(key.PublicKey.E)),
+	}}}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Header().Set("Cache-Control", "max-age=60")
+		_ = json.NewEncoder(w).Encode(doc)
+	}))
+	defer server.Close()
+
+	verifier := NewVerifier(server.URL, "", "")
+	token := signToken(t, key, "kid-1", jwt.MapClaims{
+		"jti": "job-jti-123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.ExtractJTI("Bearer " + token); err != nil {
+			t.Fatalf("unexpected error on call %d: %v", i, err)
+		}
+	}
+
+	if fetches != 1 {
+		t.Fatalf("expected JWKS to be fetched once due to caching, got %d fetches", fetches)
+	}
+}
+
+func TestHasAllRoles(t *testing.T) {
+	claims := &Claims{Roles: []string{"job:read", "job:cancel"}}
+
+	if !claims.HasAllRoles(nil) {
+		t.Fatalf("expected empty requirement to always be satisfied")
+	}
+	if !claims.HasAllRoles([]string{"job:read"}) {
+		t.Fatalf("expected claims to satisfy job:read")
+	}
+	if claims.HasAllRoles([]string{"job:admin"}) {
+		t.Fatalf("expected claims to not satisfy job:admin")
+	}
+}
+
+func TestClassifyError_Default(t *testing.T) {
+	err := classifyError(fmt.Errorf("boom"))
+	if err != ErrBadSignature {
+		t.Fatalf("expected ErrBadSignature for non validation error, got %v", err)
+	}
+}
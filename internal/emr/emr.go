@@ -0,0 +1,73 @@
+// Package emr wraps the EMR Serverless client behind an interface so
+// handlers can be tested without talking to AWS.
+package emr
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/emrserverless"
+)
+
+// StartJobRunInput is the subset of emrserverless.StartJobRunInput the
+// handlers need to specify.
+type StartJobRunInput struct {
+	ApplicationId       string
+	ExecutionRoleArn    string
+	Name                string
+	SparkEntryPoint     string
+	SparkEntryPointArgs []string
+}
+
+// StartJobRunOutput is the subset of emrserverless.StartJobRunOutput the
+// handlers need back.
+type StartJobRunOutput struct {
+	JobRunId string
+}
+
+// Client starts and cancels EMR Serverless job runs.
+type Client interface {
+	CancelJobRun(ctx context.Context, applicationId string, jobRunId string) error
+	StartJobRun(ctx context.Context, input StartJobRunInput) (StartJobRunOutput, error)
+}
+
+type serverlessClient struct {
+	service *emrserverless.EMRServerless
+}
+
+// NewClient wraps service behind the Client interface.
+func NewClient(service *emrserverless.EMRServerless) Client {
+	return &serverlessClient{service: service}
+}
+
+func (c *serverlessClient) CancelJobRun(ctx context.Context, applicationId string, jobRunId string) error {
+	_, err := c.service.CancelJobRunWithContext(ctx, &emrserverless.CancelJobRunInput{
+		ApplicationId: aws.String(applicationId),
+		JobRunId:      aws.String(jobRunId),
+	})
+	return err
+}
+
+func (c *serverlessClient) StartJobRun(ctx context.Context, input StartJobRunInput) (StartJobRunOutput, error) {
+	args := make([]*string, 0, len(input.SparkEntryPointArgs))
+	for _, arg := range input.SparkEntryPointArgs {
+		args = append(args, aws.String(arg))
+	}
+
+	output, err := c.service.StartJobRunWithContext(ctx, &emrserverless.StartJobRunInput{
+		ApplicationId:    aws.String(input.ApplicationId),
+		ExecutionRoleArn: aws.String(input.ExecutionRoleArn),
+		Name:             aws.String(input.Name),
+		JobDriver: &emrserverless.JobDriver{
+			SparkSubmit: &emrserverless.SparkSubmit{
+				EntryPoint:          aws.String(input.SparkEntryPoint),
+				EntryPointArguments: args,
+			},
+		},
+	})
+	if err != nil {
+		return StartJobRunOutput{}, err
+	}
+
+	return StartJobRunOutput{JobRunId: aws.StringValue(output.JobRunId)}, nil
+}
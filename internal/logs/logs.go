@@ -0,0 +1,170 @@
+// Package logs reads EMR Serverless driver/executor log files from S3,
+// tracking a resumable byte offset per file so a follow-style tail only
+// returns bytes appended since the last read.
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// Line is a single log line, tagged with the S3 key it came from.
+type Line struct {
+	File string
+	Text string
+}
+
+// Offsets tracks the next unread byte per log file key.
+type Offsets map[string]int64
+
+// Source reads EMR Serverless log objects out of a single S3 bucket.
+type Source struct {
+	s3     s3iface.S3API
+	bucket string
+}
+
+// NewSource builds a Source reading log objects from bucket.
+func NewSource(client s3iface.S3API, bucket string) *Source {
+	return &Source{s3: client, bucket: bucket}
+}
+
+// Backlog returns up to the last `lines` lines across every log file under
+// prefix (0 means no limit), along with the offsets a subsequent Tail should
+// resume from.
+func (s *Source) Backlog(ctx context.Context, prefix string, lines int) ([]Line, Offsets, error) {
+	keys, err := s.listKeys(ctx, prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var all []Line
+	offsets := Offsets{}
+	for _, key := range keys {
+		text, size, err := s.readFrom(ctx, key, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		offsets[key] = size
+		for _, line := range splitLines(text) {
+			all = append(all, Line{File: key, Text: line})
+		}
+	}
+
+	if lines > 0 && len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+
+	return all, offsets, nil
+}
+
+// Tail returns any lines appended to the log files under prefix since
+// offsets, plus the updated offsets.
+func (s *Source) Tail(ctx context.Context, prefix string, offsets Offsets) ([]Line, Offsets, error) {
+	keys, err := s.listKeys(ctx, prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	next := make(Offsets, len(offsets))
+	for key, offset := range offsets {
+		next[key] = offset
+	}
+
+	var newLines []Line
+	for _, key := range keys {
+		text, size, err := s.readFrom(ctx, key, next[key])
+		if err != nil {
+			return nil, nil, err
+		}
+		if size <= next[key] {
+			continue
+		}
+		next[key] = size
+		for _, line := range splitLines(text) {
+			newLines = append(newLines, Line{File: key, Text: line})
+		}
+	}
+
+	return newLines, next, nil
+}
+
+func (s *Source) listKeys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+
+	err := s.s3.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			keys = append(keys, aws.StringValue(obj.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list log objects under %s: %w", prefix, err)
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// readFrom returns the bytes of key starting at byte offset from, plus the
+// object's current total size. from >= size is not an error, it just means
+// there is nothing new yet.
+func (s *Source) readFrom(ctx context.Context, key string, from int64) (string, int64, error) {
+	head, err := s.s3.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("head log object %s: %w", key, err)
+	}
+
+	size := aws.Int64Value(head.ContentLength)
+	if from >= size {
+		return "", size, nil
+	}
+
+	output, err := s.s3.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-", from)),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "InvalidRange" {
+			return "", size, nil
+		}
+		return "", 0, fmt.Errorf("get log object %s: %w", key, err)
+	}
+	defer output.Body.Close()
+
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(output.Body); err != nil {
+		return "", 0, fmt.Errorf("read log object %s: %w", key, err)
+	}
+
+	return buf.String(), size, nil
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
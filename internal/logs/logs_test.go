@@ -0,0 +1,148 @@
+package logs
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// stubS3 is a minimal s3iface.S3API covering only the calls Source makes,
+// backed by an in-memory object store. Embedding the interface means any
+// method we don't override panics on use rather than failing to compile.
+type stubS3 struct {
+	s3iface.S3API
+	keys    []string
+	content map[string]string
+}
+
+func (s *stubS3) ListObjectsV2PagesWithContext(ctx aws.Context, input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, opts ...request.Option) error {
+	var objs []*s3.Object
+	for _, k := range s.keys {
+		key := k
+		objs = append(objs, &s3.Object{Key: &key})
+	}
+	fn(&s3.ListObjectsV2Output{Contents: objs}, true)
+	return nil
+}
+
+func (s *stubS3) HeadObjectWithContext(ctx aws.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error) {
+	body, ok := s.content[aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.New("NoSuchKey", "not found", nil)
+	}
+	size := int64(len(body))
+	return &s3.HeadObjectOutput{ContentLength: &size}, nil
+}
+
+func (s *stubS3) GetObjectWithContext(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+	body := s.content[aws.StringValue(input.Key)]
+
+	from := 0
+	if r := aws.StringValue(input.Range); r != "" {
+		from = parseRangeStart(r)
+	}
+
+	if from > len(body) {
+		return nil, awserr.New("InvalidRange", "range out of bounds", nil)
+	}
+
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(body[from:]))}, nil
+}
+
+func parseRangeStart(r string) int {
+	r = strings.TrimPrefix(r, "bytes=")
+	r = strings.TrimSuffix(r, "-")
+	n, _ := strconv.Atoi(r)
+	return n
+}
+
+func TestSplitLines_Empty(t *testing.T) {
+	if lines := splitLines(""); lines != nil {
+		t.Fatalf("expected nil lines for empty text, got %v", lines)
+	}
+}
+
+func TestSplitLines_Multiple(t *testing.T) {
+	lines := splitLines("one\ntwo\nthree")
+	if len(lines) != 3 || lines[0] != "one" || lines[2] != "three" {
+		t.Fatalf("unexpected split: %v", lines)
+	}
+}
+
+func TestBacklog_ReturnsLastNLinesAndOffsets(t *testing.T) {
+	stub := &stubS3{
+		keys: []string{"jobs/1/stdout"},
+		content: map[string]string{
+			"jobs/1/stdout": "line1\nline2\nline3\n",
+		},
+	}
+	source := NewSource(stub, "bucket")
+
+	lines, offsets, err := source.Backlog(context.Background(), "jobs/1/", 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(lines) != 2 || lines[0].Text != "line2" || lines[1].Text != "line3" {
+		t.Fatalf("unexpected backlog: %+v", lines)
+	}
+	if offsets["jobs/1/stdout"] != int64(len(stub.content["jobs/1/stdout"])) {
+		t.Fatalf("expected offset to match object size, got %+v", offsets)
+	}
+}
+
+func TestTail_OnlyReturnsBytesAppendedSinceOffset(t *testing.T) {
+	stub := &stubS3{
+		keys: []string{"jobs/1/stdout"},
+		content: map[string]string{
+			"jobs/1/stdout": "line1\nline2\n",
+		},
+	}
+	source := NewSource(stub, "bucket")
+
+	_, offsets, err := source.Backlog(context.Background(), "jobs/1/", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stub.content["jobs/1/stdout"] += "line3\n"
+
+	newLines, next, err := source.Tail(context.Background(), "jobs/1/", offsets)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(newLines) != 1 || newLines[0].Text != "line3" {
+		t.Fatalf("expected only the appended line, got %+v", newLines)
+	}
+	if next["jobs/1/stdout"] != int64(len(stub.content["jobs/1/stdout"])) {
+		t.Fatalf("expected updated offset, got %+v", next)
+	}
+}
+
+func TestTail_NoNewBytesReturnsNoLines(t *testing.T) {
+	stub := &stubS3{
+		keys:    []string{"jobs/1/stdout"},
+		content: map[string]string{"jobs/1/stdout": "line1\n"},
+	}
+	source := NewSource(stub, "bucket")
+
+	_, offsets, err := source.Backlog(context.Background(), "jobs/1/", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	newLines, _, err := source.Tail(context.Background(), "jobs/1/", offsets)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(newLines) != 0 {
+		t.Fatalf("expected no new lines, got %+v", newLines)
+	}
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RetryResult identifies the job row created by InsertRetry.
+type RetryResult struct {
+	Id    string
+	JobId string
+}
+
+// Get fetches a single job by id.
+func (r *JobRepository) Get(ctx context.Context, id string) (Job, error) {
+	statement := `SELECT id, jobid, jobstatus, requestid, query, destination, jti, cross_bucket_region FROM emr_job_details WHERE id=$1`
+
+	var job Job
+	row := r.db.QueryRowContext(ctx, statement, id)
+
+	switch err := row.Scan(
+		&job.Id,
+		&job.JobId,
+		&job.JobStatus,
+		&job.RequestId,
+		&job.Query,
+		&job.Destination,
+		&job.Jti,
+		&job.Region,
+	); err {
+	case sql.ErrNoRows:
+		return job, sql.ErrNoRows
+	case nil:
+		return job, nil
+	default:
+		return job, err
+	}
+}
+
+// InsertRetry records a new job row resubmitted from original, linked back
+// to it via parent_id, and returns the new row's id.
+func (r *JobRepository) InsertRetry(ctx context.Context, original Job, newJobId string, newRequestId string) (string, error) {
+	statement := `INSERT INTO emr_job_details (jobid, jobstatus, requestid, query, destination, jti, cross_bucket_region, parent_id)
+	              VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+
+	var newId string
+	err := r.db.QueryRowContext(
+		ctx,
+		statement,
+		newJobId,
+		"SUBMITTED",
+		newRequestId,
+		original.Query,
+		original.Destination,
+		original.Jti,
+		original.Region,
+		original.Id,
+	).Scan(&newId)
+
+	return newId, err
+}
+
+// LookupIdempotencyKey returns the retry previously created for key, if any
+// unexpired mapping with a finalized result exists. A key that has been
+// reserved (see ReserveIdempotencyKey) but not yet finalized is reported as
+// not found, since its result isn't known yet.
+func (r *JobRepository) LookupIdempotencyKey(ctx context.Context, key string) (RetryResult, bool, error) {
+	statement := `SELECT new_id, new_job_id FROM idempotency WHERE key=$1 AND expires_at > now() AND new_job_id <> ''`
+
+	var result RetryResult
+	switch err := r.db.QueryRowContext(ctx, statement, key).Scan(&result.Id, &result.JobId); err {
+	case sql.ErrNoRows:
+		return RetryResult{}, false, nil
+	case nil:
+		return result, true, nil
+	default:
+		return RetryResult{}, false, err
+	}
+}
+
+// ReserveIdempotencyKey atomically claims key for a new retry attempt. It
+// returns true if the caller won the race and is responsible for carrying
+// out the retry and recording its result with FinalizeIdempotencyKey; it
+// returns false if another caller already holds an unexpired claim on key,
+// in which case the caller must not start a new retry. Reserving is a
+// single INSERT with a unique constraint on key, so concurrent callers with
+// the same key can never both win.
+func (r *JobRepository) ReserveIdempotencyKey(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	statement := `INSERT INTO idempotency (key, new_id, new_job_id, expires_at) VALUES ($1, '', '', $2)
+	              ON CONFLICT (key) DO UPDATE SET new_id = '', new_job_id = '', expires_at = $2
+	              WHERE idempotency.expires_at <= now()`
+
+	result, err := r.db.ExecContext(ctx, statement, key, time.Now().Add(ttl))
+	if err != nil {
+		return false, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// FinalizeIdempotencyKey records result for a key previously won via
+// ReserveIdempotencyKey, making it visible to LookupIdempotencyKey.
+func (r *JobRepository) FinalizeIdempotencyKey(ctx context.Context, key string, result RetryResult) error {
+	statement := `UPDATE idempotency SET new_id = $2, new_job_id = $3 WHERE key = $1`
+
+	_, err := r.db.ExecContext(ctx, statement, key, result.Id, result.JobId)
+	if err != nil {
+		return fmt.Errorf("finalize idempotency key: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes a reservation made by ReserveIdempotencyKey
+// that was never finalized, e.g. because the retry it was guarding with the
+// reservation failed before producing a result. This frees key for another
+// attempt instead of leaving it claimed-but-unfinalized for the rest of its
+// TTL. It is a no-op if the key was already finalized or released.
+func (r *JobRepository) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	statement := `DELETE FROM idempotency WHERE key = $1 AND new_job_id = ''`
+
+	_, err := r.db.ExecContext(ctx, statement, key)
+	if err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
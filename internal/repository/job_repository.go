@@ -0,0 +1,170 @@
+// Package repository provides Postgres-backed access to emr_job_details.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// Job is a single row from emr_job_details.
+type Job struct {
+	Id          string    `json:"id"`
+	JobId       string    `json:"jobId"`
+	JobStatus   string    `json:"jobStatus"`
+	RequestId   string    `json:"requestId"`
+	Query       string    `json:"query"`
+	Destination string    `json:"destination"`
+	Jti         string    `json:"jti"`
+	Region      string    `json:"cross_bucket_region"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Filter selects and paginates the jobs belonging to a single caller (Jti).
+type Filter struct {
+	Jti         string
+	JobStatus   string
+	Destination string
+	From        time.Time
+	To          time.Time
+	Limit       int
+	Cursor      string
+}
+
+// Page is one page of a List result.
+type Page struct {
+	Items      []Job  `json:"items"`
+	NextCursor string `json:"nextCursor"`
+}
+
+// JobRepository reads and inserts emr_job_details rows and manages the
+// idempotency table used by retries.
+type JobRepository struct {
+	db *sql.DB
+}
+
+// NewJobRepository builds a JobRepository backed by db.
+func NewJobRepository(db *sql.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// cursor is the decoded form of the opaque, base64-encoded pagination cursor.
+type cursor struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Id        string    `json:"id"`
+}
+
+func encodeCursor(c cursor) string {
+	body, _ := json.Marshal(c)
+	return base64.URLEncoding.EncodeToString(body)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	body, err := base64.URLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if err := json.Unmarshal(body, &c); err != nil {
+		return c, fmt.Errorf("invalid cursor: %w", err)
+	}
+	return c, nil
+}
+
+// List returns jobs matching filter, ordered by (created_at, id) descending,
+// along with an opaque cursor for the next page (empty when there isn't one).
+func (r *JobRepository) List(ctx context.Context, filter Filter) (Page, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	conditions := []string{"jti = $1"}
+	args := []interface{}{filter.Jti}
+
+	if filter.JobStatus != "" {
+		args = append(args, filter.JobStatus)
+		conditions = append(conditions, fmt.Sprintf("jobstatus = $%d", len(args)))
+	}
+	if filter.Destination != "" {
+		args = append(args, filter.Destination)
+		conditions = append(conditions, fmt.Sprintf("destination = $%d", len(args)))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		args = append(args, c.CreatedAt, c.Id)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	args = append(args, limit+1)
+
+	statement := fmt.Sprintf(
+		`SELECT id, jobid, jobstatus, requestid, query, destination, jti, cross_bucket_region, created_at
+		 FROM emr_job_details
+		 WHERE %s
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $%d`,
+		strings.Join(conditions, " AND "),
+		len(args),
+	)
+
+	rows, err := r.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return Page{}, fmt.Errorf("list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var job Job
+		if err := rows.Scan(
+			&job.Id,
+			&job.JobId,
+			&job.JobStatus,
+			&job.RequestId,
+			&job.Query,
+			&job.Destination,
+			&job.Jti,
+			&job.Region,
+			&job.CreatedAt,
+		); err != nil {
+			return Page{}, fmt.Errorf("scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return Page{}, fmt.Errorf("list jobs: %w", err)
+	}
+
+	page := Page{Items: jobs}
+	if len(jobs) > limit {
+		last := jobs[limit-1]
+		page.Items = jobs[:limit]
+		page.NextCursor = encodeCursor(cursor{CreatedAt: last.CreatedAt, Id: last.Id})
+	}
+
+	return page, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestList_ReturnsItemsWithoutNextCursorWhenUnderLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	createdAt := time.Date(2026, 7, 20, 10, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "jobid", "jobstatus", "requestid", "query", "destination", "jti", "cross_bucket_region", "created_at"}).
+		AddRow("job-1", "jr-1", "SUCCESS", "req-1", "select 1", "bucket/a", "jti-1", "us-east-1", createdAt)
+
+	mock.ExpectQuery("SELECT (.+) FROM emr_job_details").
+		WithArgs("jti-1", 51).
+		WillReturnRows(rows)
+
+	repo := NewJobRepository(db)
+	page, err := repo.List(context.Background(), Filter{Jti: "jti-1"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(page.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(page.Items))
+	}
+	if page.NextCursor != "" {
+		t.Fatalf("expected no next cursor, got %q", page.NextCursor)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestList_ReturnsNextCursorWhenOverLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	first := time.Date(2026, 7, 20, 10, 0, 0, 0, time.UTC)
+	second := time.Date(2026, 7, 19, 10, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "jobid", "jobstatus", "requestid", "query", "destination", "jti", "cross_bucket_region", "created_at"}).
+		AddRow("job-1", "jr-1", "SUCCESS", "req-1", "select 1", "bucket/a", "jti-1", "us-east-1", first).
+		AddRow("job-2", "jr-2", "RUNNING", "req-2", "select 2", "bucket/b", "jti-1", "us-east-1", second)
+
+	mock.ExpectQuery("SELECT (.+) FROM emr_job_details").
+		WithArgs("jti-1", 2).
+		WillReturnRows(rows)
+
+	repo := NewJobRepository(db)
+	page, err := repo.List(context.Background(), Filter{Jti: "jti-1", Limit: 1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(page.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(page.Items))
+	}
+	if page.NextCursor == "" {
+		t.Fatalf("expected a next cursor when results exceed the limit")
+	}
+
+	decoded, err := decodeCursor(page.NextCursor)
+	if err != nil {
+		t.Fatalf("expected cursor to decode, got %v", err)
+	}
+	if decoded.Id != "job-1" || !decoded.CreatedAt.Equal(first) {
+		t.Fatalf("unexpected cursor contents: %+v", decoded)
+	}
+}
+
+func TestList_InvalidCursorIsRejected(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewJobRepository(db)
+	_, err = repo.List(context.Background(), Filter{Jti: "jti-1", Cursor: "not-base64!!"})
+	if err == nil {
+		t.Fatalf("expected an error for an invalid cursor")
+	}
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGet_ReturnsJob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "jobid", "jobstatus", "requestid", "query", "destination", "jti", "cross_bucket_region"}).
+		AddRow("job-1", "jr-1", "SUCCESS", "req-1", "select 1", "bucket/a", "jti-1", "us-east-1")
+
+	mock.ExpectQuery("SELECT (.+) FROM emr_job_details WHERE id=\\$1").
+		WithArgs("job-1").
+		WillReturnRows(rows)
+
+	repo := NewJobRepository(db)
+	job, err := repo.Get(context.Background(), "job-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if job.JobId != "jr-1" || job.JobStatus != "SUCCESS" {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+}
+
+func TestGet_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM emr_job_details WHERE id=\\$1").
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	repo := NewJobRepository(db)
+	_, err = repo.Get(context.Background(), "missing")
+	if err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestInsertRetry_ReturnsNewId(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	original := Job{Id: "job-1", Query: "select 1", Destination: "bucket/a", Jti: "jti-1", Region: "us-east-1"}
+
+	mock.ExpectQuery("INSERT INTO emr_job_details").
+		WithArgs("jr-2", "SUBMITTED", "req-2", original.Query, original.Destination, original.Jti, original.Region, original.Id).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("job-2"))
+
+	repo := NewJobRepository(db)
+	newId, err := repo.InsertRetry(context.Background(), original, "jr-2", "req-2")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if newId != "job-2" {
+		t.Fatalf("expected job-2, got %v", newId)
+	}
+}
+
+func TestLookupIdempotencyKey_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT new_id, new_job_id FROM idempotency").
+		WithArgs("key-1").
+		WillReturnError(sql.ErrNoRows)
+
+	repo := NewJobRepository(db)
+	_, found, err := repo.LookupIdempotencyKey(context.Background(), "key-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if found {
+		t.Fatalf("expected not found")
+	}
+}
+
+func TestReserveIdempotencyKey_WinsWhenUnclaimed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO idempotency").
+		WithArgs("key-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := NewJobRepository(db)
+	reserved, err := repo.ReserveIdempotencyKey(context.Background(), "key-1", time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !reserved {
+		t.Fatalf("expected to win an uncontested reservation")
+	}
+}
+
+func TestReserveIdempotencyKey_LosesToLiveClaim(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO idempotency").
+		WithArgs("key-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := NewJobRepository(db)
+	reserved, err := repo.ReserveIdempotencyKey(context.Background(), "key-1", time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if reserved {
+		t.Fatalf("expected to lose to an already-live reservation")
+	}
+}
+
+func TestFinalizeIdempotencyKey_Updates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE idempotency SET new_id").
+		WithArgs("key-1", "job-2", "jr-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := NewJobRepository(db)
+	err = repo.FinalizeIdempotencyKey(context.Background(), "key-1", RetryResult{Id: "job-2", JobId: "jr-2"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestReleaseIdempotencyKey_Deletes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM idempotency").
+		WithArgs("key-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := NewJobRepository(db)
+	err = repo.ReleaseIdempotencyKey(context.Background(), "key-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
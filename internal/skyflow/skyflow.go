@@ -0,0 +1,61 @@
+// Package skyflow calls the Skyflow vault management API to authorize a
+// request before it is allowed to touch a job.
+package skyflow
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// AuthorizationResponse is the outcome of an authorization call. Error is
+// only set when the HTTP call itself failed (not on a non-200 status, which
+// is reported via StatusCode/ResponseBody instead).
+type AuthorizationResponse struct {
+	RequestId    string `json:"requestId"`
+	StatusCode   int    `json:"statusCode"`
+	ResponseBody string `json:"responseBody"`
+	Error        string `json:"error"`
+}
+
+// Client calls the Skyflow vault management API.
+type Client struct {
+	managementUrl string
+	httpClient    *http.Client
+}
+
+// NewClient builds a Client that talks to managementUrl.
+func NewClient(managementUrl string) *Client {
+	return &Client{
+		managementUrl: managementUrl,
+		httpClient:    &http.Client{Timeout: 1 * time.Minute},
+	}
+}
+
+// Authorize checks that token is authorized to act against vaultId.
+func (c *Client) Authorize(token string, vaultId string) AuthorizationResponse {
+	var authResponse AuthorizationResponse
+
+	url := c.managementUrl + "/v1/vaults/" + vaultId
+
+	request, _ := http.NewRequest("GET", url, nil)
+	request.Header.Add("Accept", "apaplication/json")
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("Authorization", token)
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		authResponse.StatusCode = http.StatusInternalServerError
+		authResponse.Error = err.Error()
+		return authResponse
+	}
+	defer response.Body.Close()
+
+	responseBody, _ := io.ReadAll(response.Body)
+
+	authResponse.RequestId = response.Header.Get("x-request-id")
+	authResponse.StatusCode = response.StatusCode
+	authResponse.ResponseBody = string(responseBody)
+
+	return authResponse
+}
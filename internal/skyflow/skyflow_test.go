@@ -0,0 +1,50 @@
+package skyflow
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthorize_ReturnsBodyAndStatusOnSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/vaults/vault-1" {
+			t.Fatalf("unexpected path: %v", r.URL.Path)
+		}
+		w.Header().Set("x-request-id", "req-1")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	response := client.Authorize("Bearer token", "vault-1")
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %v", response.StatusCode)
+	}
+	if response.RequestId != "req-1" {
+		t.Fatalf("expected request id req-1, got %v", response.RequestId)
+	}
+	if response.ResponseBody != `{"ok":true}` {
+		t.Fatalf("unexpected body: %v", response.ResponseBody)
+	}
+}
+
+func TestAuthorize_PassesThroughNonOKStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":"forbidden"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	response := client.Authorize("Bearer token", "vault-1")
+
+	if response.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got %v", response.StatusCode)
+	}
+	if response.Error != "" {
+		t.Fatalf("expected no transport error, got %v", response.Error)
+	}
+}